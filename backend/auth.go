@@ -0,0 +1,167 @@
+package main
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/spruceid/siwe-go"
+)
+
+// callerAddressContextKey is the gin context key the SIWE-verified caller
+// address is stored under once authMiddleware accepts a request.
+const callerAddressContextKey = "caller_address"
+
+// callerAddressHeader carries the verified caller address through to the
+// KRNL node on the outgoing request.
+const callerAddressHeader = "X-Caller-Address"
+
+// AuthConfig controls SIWE session issuance and rate limiting.
+type AuthConfig struct {
+	JWTSecret       string          `yaml:"jwt_secret"`
+	Domain          string          `yaml:"domain"`
+	TokenTTL        Duration        `yaml:"token_ttl"`
+	RateLimit       RateLimitConfig `yaml:"rate_limit"`
+	GlobalRateLimit RateLimitConfig `yaml:"global_rate_limit"`
+}
+
+// RateLimitConfig is a token-bucket rate limit: RateLimit applies it per
+// caller address, GlobalRateLimit once across the whole gateway.
+type RateLimitConfig struct {
+	RPS   float64 `yaml:"rps"`
+	Burst int     `yaml:"burst"`
+}
+
+// jwtSigningSecret resolves the key used to sign session tokens, from
+// config or the KRNL_JWT_SECRET env var, falling back to a random key
+// generated at startup (sessions won't survive a restart in that case).
+func jwtSigningSecret(cfg AuthConfig) []byte {
+	if cfg.JWTSecret != "" {
+		return []byte(cfg.JWTSecret)
+	}
+	if fromEnv := os.Getenv("KRNL_JWT_SECRET"); fromEnv != "" {
+		return []byte(fromEnv)
+	}
+
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		panic(fmt.Sprintf("generate JWT signing secret: %v", err))
+	}
+	logger.Warn("no auth.jwt_secret or KRNL_JWT_SECRET set, generated an ephemeral signing key; sessions will not survive a restart")
+	return secret
+}
+
+func defaultAuthConfig() AuthConfig {
+	return AuthConfig{
+		TokenTTL: Duration(15 * time.Minute),
+		RateLimit: RateLimitConfig{
+			RPS:   5,
+			Burst: 10,
+		},
+		GlobalRateLimit: RateLimitConfig{
+			RPS:   50,
+			Burst: 100,
+		},
+	}
+}
+
+type sessionClaims struct {
+	Address string `json:"address"`
+	jwt.RegisteredClaims
+}
+
+// loginRequest is the body of POST /api/auth/login: a Sign-In-With-Ethereum
+// (EIP-4361) message plus the signature over it.
+type loginRequest struct {
+	Message   string `json:"message"`
+	Signature string `json:"signature"`
+}
+
+type loginResponse struct {
+	Token     string    `json:"token"`
+	Address   string    `json:"address"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// verifySIWE parses rawMessage as an EIP-4361 message, checks the signature
+// against it along with the message's domain/expiration/not-before fields,
+// and then redeems its nonce against nonces so a captured (message,
+// signature) pair issued for a one-time login can't be replayed.
+func verifySIWE(rawMessage, signature, expectedDomain string, nonces *nonceStore) (string, error) {
+	msg, err := siwe.ParseMessage(rawMessage)
+	if err != nil {
+		return "", fmt.Errorf("parse SIWE message: %w", err)
+	}
+
+	var domainPtr *string
+	if expectedDomain != "" {
+		domainPtr = &expectedDomain
+	}
+	now := time.Now().UTC()
+	if _, err := msg.Verify(signature, domainPtr, nil, &now); err != nil {
+		return "", fmt.Errorf("verify signature: %w", err)
+	}
+
+	if !nonces.consume(msg.GetNonce()) {
+		return "", errors.New("nonce was not issued by this server or has already been used")
+	}
+
+	return msg.GetAddress().Hex(), nil
+}
+
+// issueToken mints a JWT bound to address, valid for ttl.
+func issueToken(secret []byte, address string, ttl time.Duration) (string, time.Time, error) {
+	expiresAt := time.Now().Add(ttl)
+	claims := sessionClaims{
+		Address: address,
+		RegisteredClaims: jwt.RegisteredClaims{
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+		},
+	}
+	signed, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(secret)
+	return signed, expiresAt, err
+}
+
+// parseToken validates raw as a session JWT signed with secret and returns
+// the bound caller address.
+func parseToken(secret []byte, raw string) (string, error) {
+	claims := &sessionClaims{}
+	token, err := jwt.ParseWithClaims(raw, claims, func(t *jwt.Token) (any, error) {
+		if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method: %v", t.Header["alg"])
+		}
+		return secret, nil
+	})
+	if err != nil || !token.Valid {
+		return "", errors.New("invalid or expired session token")
+	}
+	return claims.Address, nil
+}
+
+// authMiddleware requires a valid session JWT on the Authorization header
+// and stores the recovered caller address in the request context.
+func authMiddleware(secret []byte) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		header := c.GetHeader("Authorization")
+		const prefix = "Bearer "
+		if !strings.HasPrefix(header, prefix) {
+			c.AbortWithStatusJSON(401, gin.H{"error": "missing bearer token"})
+			return
+		}
+
+		address, err := parseToken(secret, strings.TrimPrefix(header, prefix))
+		if err != nil {
+			c.AbortWithStatusJSON(401, gin.H{"error": "invalid or expired session token"})
+			return
+		}
+
+		c.Set(callerAddressContextKey, address)
+		c.Next()
+	}
+}
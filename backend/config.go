@@ -0,0 +1,175 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// defaultConfigPath is used when neither -c nor KRNL_CONFIG_PATH is set.
+const defaultConfigPath = "config.yaml"
+
+// Duration parses YAML/JSON duration strings ("60s", "200ms") into a
+// time.Duration, since neither format does this natively.
+type Duration time.Duration
+
+func (d *Duration) UnmarshalYAML(unmarshal func(any) error) error {
+	var s string
+	if err := unmarshal(&s); err != nil {
+		return err
+	}
+	parsed, err := time.ParseDuration(s)
+	if err != nil {
+		return fmt.Errorf("invalid duration %q: %w", s, err)
+	}
+	*d = Duration(parsed)
+	return nil
+}
+
+// EndpointConfig describes one upstream KRNL node and which methods or
+// workflow IDs should be routed to it.
+type EndpointConfig struct {
+	Name        string   `yaml:"name"`
+	URL         string   `yaml:"url"`
+	Methods     []string `yaml:"methods,omitempty"`
+	WorkflowIDs []string `yaml:"workflow_ids,omitempty"`
+	Timeout     Duration `yaml:"timeout"`
+}
+
+// RetryConfig controls how failed calls to an endpoint are retried.
+type RetryConfig struct {
+	MaxAttempts int      `yaml:"max_attempts"`
+	BaseDelay   Duration `yaml:"base_delay"`
+	MaxDelay    Duration `yaml:"max_delay"`
+}
+
+// BreakerConfig controls the circuit breaker guarding each endpoint.
+type BreakerConfig struct {
+	FailureThreshold uint32   `yaml:"failure_threshold"`
+	Window           Duration `yaml:"window"`
+	OpenTimeout      Duration `yaml:"open_timeout"`
+}
+
+// Config is the top-level gateway configuration, loaded from a YAML (or
+// JSON, which is valid YAML) file.
+type Config struct {
+	Endpoints  []EndpointConfig `yaml:"endpoints"`
+	Retry      RetryConfig      `yaml:"retry"`
+	Breaker    BreakerConfig    `yaml:"breaker"`
+	Validation ValidationConfig `yaml:"validation"`
+	Auth       AuthConfig       `yaml:"auth"`
+}
+
+// defaultConfig reproduces the previous hard-coded single-endpoint
+// behaviour, used when no config file is found.
+func defaultConfig() *Config {
+	return &Config{
+		Endpoints: []EndpointConfig{
+			{
+				Name:    "default",
+				URL:     "https://v0-1-0.node.lat/",
+				Timeout: Duration(60 * time.Second),
+			},
+		},
+		Retry: RetryConfig{
+			MaxAttempts: 1,
+			BaseDelay:   Duration(200 * time.Millisecond),
+			MaxDelay:    Duration(2 * time.Second),
+		},
+		Breaker: BreakerConfig{
+			FailureThreshold: 5,
+			Window:           Duration(30 * time.Second),
+			OpenTimeout:      Duration(30 * time.Second),
+		},
+		Validation: defaultValidationConfig(),
+		Auth:       defaultAuthConfig(),
+	}
+}
+
+// loadConfig reads the gateway config from path. An empty, non-explicit
+// path falls back to defaultConfigPath, and a missing file there yields
+// defaultConfig rather than an error — but a path the operator explicitly
+// supplied (via -c or KRNL_CONFIG_PATH) must exist; a typo there fails
+// startup instead of silently reverting to the single hardcoded endpoint.
+func loadConfig(path string, explicit bool) (*Config, error) {
+	if path == "" {
+		path = defaultConfigPath
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			if explicit {
+				return nil, fmt.Errorf("config file %s not found", path)
+			}
+			return defaultConfig(), nil
+		}
+		return nil, fmt.Errorf("read config %s: %w", path, err)
+	}
+
+	cfg := defaultConfig()
+	cfg.Endpoints = nil
+	if err := yaml.Unmarshal(data, cfg); err != nil {
+		return nil, fmt.Errorf("parse config %s: %w", path, err)
+	}
+	if len(cfg.Endpoints) == 0 {
+		return nil, fmt.Errorf("config %s declares no endpoints", path)
+	}
+	if cfg.Retry.MaxAttempts <= 0 {
+		cfg.Retry.MaxAttempts = 1
+	}
+	if cfg.Validation.MaxBodyBytes <= 0 {
+		cfg.Validation.MaxBodyBytes = defaultValidationConfig().MaxBodyBytes
+	}
+	if cfg.Auth.TokenTTL <= 0 {
+		cfg.Auth.TokenTTL = defaultAuthConfig().TokenTTL
+	}
+	if cfg.Auth.RateLimit.RPS <= 0 {
+		cfg.Auth.RateLimit = defaultAuthConfig().RateLimit
+	}
+	if cfg.Auth.GlobalRateLimit.RPS <= 0 {
+		cfg.Auth.GlobalRateLimit = defaultAuthConfig().GlobalRateLimit
+	}
+	return cfg, nil
+}
+
+// endpointFor picks the endpoint configured for the given JSON-RPC method
+// and workflow ID, falling back to the first declared endpoint.
+func (c *Config) endpointFor(method, workflowID string) EndpointConfig {
+	for _, ep := range c.Endpoints {
+		if workflowID != "" && containsString(ep.WorkflowIDs, workflowID) {
+			return ep
+		}
+	}
+	for _, ep := range c.Endpoints {
+		if containsString(ep.Methods, method) {
+			return ep
+		}
+	}
+	return c.Endpoints[0]
+}
+
+// extractWorkflowID pulls an "id" field out of an arbitrary workflow
+// payload, if present, so requests can be routed per workflow ID.
+func extractWorkflowID(workflow any) string {
+	obj, ok := workflow.(map[string]any)
+	if !ok {
+		return ""
+	}
+	id, ok := obj["id"].(string)
+	if !ok {
+		return ""
+	}
+	return id
+}
+
+func containsString(haystack []string, needle string) bool {
+	for _, s := range haystack {
+		if s == needle {
+			return true
+		}
+	}
+	return false
+}
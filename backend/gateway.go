@@ -0,0 +1,217 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"math/big"
+	"net/http"
+	"time"
+
+	"github.com/sony/gobreaker"
+)
+
+// gateway holds the routing, retry and circuit-breaker state built from the
+// loaded Config. One gateway is constructed at startup and shared across
+// requests.
+type gateway struct {
+	cfg      *Config
+	breakers map[string]*gobreaker.CircuitBreaker
+	clients  map[string]*http.Client
+}
+
+func newGateway(cfg *Config) *gateway {
+	g := &gateway{
+		cfg:      cfg,
+		breakers: make(map[string]*gobreaker.CircuitBreaker, len(cfg.Endpoints)),
+		clients:  make(map[string]*http.Client, len(cfg.Endpoints)),
+	}
+
+	for _, ep := range cfg.Endpoints {
+		ep := ep
+		g.breakers[ep.Name] = gobreaker.NewCircuitBreaker(gobreaker.Settings{
+			Name:        ep.Name,
+			Interval:    time.Duration(cfg.Breaker.Window),
+			Timeout:     time.Duration(cfg.Breaker.OpenTimeout),
+			ReadyToTrip: func(counts gobreaker.Counts) bool {
+				return counts.ConsecutiveFailures >= cfg.Breaker.FailureThreshold
+			},
+		})
+		g.clients[ep.Name] = &http.Client{Timeout: time.Duration(ep.Timeout)}
+	}
+
+	return g
+}
+
+// upstreamResult is what a single attempt against an endpoint produces.
+type upstreamResult struct {
+	statusCode int
+	body       []byte
+}
+
+// nonIdempotentMethods holds JSON-RPC methods that must not be retried
+// automatically: krnl_executeWorkflow can move funds, and retrying it after
+// an ambiguous failure (e.g. the response was lost but the call landed)
+// risks executing the same workflow twice with no idempotency key to dedup
+// against.
+var nonIdempotentMethods = map[string]bool{
+	"krnl_executeWorkflow": true,
+}
+
+// call forwards requestBody to the endpoint selected for method/workflowID,
+// retrying transient failures with exponential backoff and jitter while the
+// endpoint's circuit breaker is closed. Non-idempotent methods (see
+// nonIdempotentMethods) are never retried, regardless of Retry.MaxAttempts.
+func (g *gateway) call(ctx context.Context, method, workflowID, requestID, callerAddress string, requestBody []byte) (*upstreamResult, error) {
+	ep := g.cfg.endpointFor(method, workflowID)
+	breaker := g.breakers[ep.Name]
+	client := g.clients[ep.Name]
+
+	maxAttempts := g.cfg.Retry.MaxAttempts
+	if nonIdempotentMethods[method] {
+		maxAttempts = 1
+	}
+
+	var lastErr error
+	for attempt := 0; attempt < maxAttempts; attempt++ {
+		if attempt > 0 {
+			if err := sleepWithJitter(ctx, backoffDelay(g.cfg.Retry, attempt)); err != nil {
+				return nil, err
+			}
+		}
+
+		out, err := breaker.Execute(func() (any, error) {
+			return g.doOnce(ctx, ep, requestID, callerAddress, requestBody, client)
+		})
+		if err == nil {
+			return out.(*upstreamResult), nil
+		}
+
+		lastErr = err
+		if err == gobreaker.ErrOpenState || err == gobreaker.ErrTooManyRequests {
+			return nil, err
+		}
+	}
+
+	return nil, lastErr
+}
+
+// doOnce performs a single HTTP attempt against an endpoint. A 5xx response
+// that carries a parseable JSON-RPC envelope (even one whose `error` field
+// is set) is a legitimate application-level response, not a transport
+// failure, so it's passed back as a result rather than retried — that
+// would both lose the original id/jsonrpc/error fields and risk re-running
+// a non-idempotent call. Only a 5xx with no parseable envelope (a true
+// transport-level failure) is treated as retryable.
+func (g *gateway) doOnce(ctx context.Context, ep EndpointConfig, requestID, callerAddress string, requestBody []byte, client *http.Client) (*upstreamResult, error) {
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", ep.URL, bytes.NewBuffer(requestBody))
+	if err != nil {
+		return nil, fmt.Errorf("build request: %w", err)
+	}
+	httpReq.Header.Set("Accept", "application/vnd.oci.image.manifest.v1+json")
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set(requestIDHeader, requestID)
+	if callerAddress != "" {
+		httpReq.Header.Set(callerAddressHeader, callerAddress)
+	}
+
+	resp, err := client.Do(httpReq)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("read response: %w", err)
+	}
+
+	if resp.StatusCode >= 500 && !hasJSONRPCEnvelope(body) {
+		return nil, fmt.Errorf("upstream returned %d", resp.StatusCode)
+	}
+
+	return &upstreamResult{statusCode: resp.StatusCode, body: body}, nil
+}
+
+// hasJSONRPCEnvelope reports whether body parses as a JSON-RPC 2.0 response
+// envelope, i.e. it's a legitimate application-level reply and not a
+// transport-level failure (proxy error page, truncated body, and so on).
+func hasJSONRPCEnvelope(body []byte) bool {
+	var rpcResp JSONRPCResponse
+	if err := json.Unmarshal(body, &rpcResp); err != nil {
+		return false
+	}
+	return rpcResp.JsonRPC != "" || rpcResp.Error != nil || rpcResp.Result != nil
+}
+
+// openStream performs a single breaker-guarded request against the endpoint
+// selected for method/workflowID and returns the live HTTP response for the
+// caller to read as an SSE/ndjson stream, along with the endpoint it was
+// sent to. Unlike call, it never retries: a streaming response can't be
+// buffered and replayed, and krnl_executeWorkflow isn't idempotent — so a
+// single attempt is made, still protected by the endpoint's circuit
+// breaker. The caller is responsible for closing the response body.
+func (g *gateway) openStream(ctx context.Context, method, workflowID, requestID, callerAddress string, requestBody []byte) (*http.Response, EndpointConfig, error) {
+	ep := g.cfg.endpointFor(method, workflowID)
+	breaker := g.breakers[ep.Name]
+	client := g.clients[ep.Name]
+
+	out, err := breaker.Execute(func() (any, error) {
+		httpReq, err := http.NewRequestWithContext(ctx, "POST", ep.URL, bytes.NewBuffer(requestBody))
+		if err != nil {
+			return nil, fmt.Errorf("build request: %w", err)
+		}
+		httpReq.Header.Set("Accept", "application/x-ndjson")
+		httpReq.Header.Set("Content-Type", "application/json")
+		httpReq.Header.Set(requestIDHeader, requestID)
+		if callerAddress != "" {
+			httpReq.Header.Set(callerAddressHeader, callerAddress)
+		}
+		return client.Do(httpReq)
+	})
+	if err != nil {
+		return nil, ep, err
+	}
+	return out.(*http.Response), ep, nil
+}
+
+// breakerStates reports the current state of every endpoint's circuit
+// breaker, for surfacing on /health.
+func (g *gateway) breakerStates() map[string]string {
+	states := make(map[string]string, len(g.breakers))
+	for name, b := range g.breakers {
+		states[name] = b.State().String()
+	}
+	return states
+}
+
+func backoffDelay(r RetryConfig, attempt int) time.Duration {
+	base := time.Duration(r.BaseDelay)
+	maxDelay := time.Duration(r.MaxDelay)
+	delay := base * time.Duration(math.Pow(2, float64(attempt-1)))
+	if delay > maxDelay {
+		delay = maxDelay
+	}
+	return delay
+}
+
+// sleepWithJitter waits delay plus a random jitter of up to 20% of delay, or
+// returns early if ctx is cancelled.
+func sleepWithJitter(ctx context.Context, delay time.Duration) error {
+	jitter := time.Duration(0)
+	if delay > 0 {
+		if n, err := rand.Int(rand.Reader, big.NewInt(int64(delay)/5+1)); err == nil {
+			jitter = time.Duration(n.Int64())
+		}
+	}
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(delay + jitter):
+		return nil
+	}
+}
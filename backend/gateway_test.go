@@ -0,0 +1,79 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBackoffDelay(t *testing.T) {
+	retry := RetryConfig{
+		BaseDelay: Duration(200 * time.Millisecond),
+		MaxDelay:  Duration(2 * time.Second),
+	}
+
+	cases := []struct {
+		attempt int
+		want    time.Duration
+	}{
+		{1, 200 * time.Millisecond},
+		{2, 400 * time.Millisecond},
+		{3, 800 * time.Millisecond},
+		{4, 1600 * time.Millisecond},
+		{5, 2 * time.Second}, // would be 3.2s uncapped, clamped to MaxDelay
+		{10, 2 * time.Second},
+	}
+
+	for _, tc := range cases {
+		if got := backoffDelay(retry, tc.attempt); got != tc.want {
+			t.Errorf("backoffDelay(attempt=%d) = %v, want %v", tc.attempt, got, tc.want)
+		}
+	}
+}
+
+func TestConfigEndpointFor(t *testing.T) {
+	cfg := &Config{
+		Endpoints: []EndpointConfig{
+			{Name: "default", URL: "https://default.example/"},
+			{
+				Name:        "fast-lane",
+				URL:         "https://fast.example/",
+				Methods:     []string{"krnl_executeWorkflow"},
+				WorkflowIDs: []string{"real-estate-deposit"},
+			},
+		},
+	}
+
+	cases := []struct {
+		name       string
+		method     string
+		workflowID string
+		want       string
+	}{
+		{"matches workflow id", "krnl_executeWorkflow", "real-estate-deposit", "fast-lane"},
+		{"matches method with no workflow id", "krnl_executeWorkflow", "", "fast-lane"},
+		{"unmatched workflow id falls back to method", "krnl_executeWorkflow", "other-workflow", "fast-lane"},
+		{"unmatched method falls back to first endpoint", "krnl_unknownMethod", "", "default"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := cfg.endpointFor(tc.method, tc.workflowID)
+			if got.Name != tc.want {
+				t.Errorf("endpointFor(%q, %q) = %q, want %q", tc.method, tc.workflowID, got.Name, tc.want)
+			}
+		})
+	}
+}
+
+func TestConfigEndpointFor_SingleEndpoint(t *testing.T) {
+	cfg := &Config{
+		Endpoints: []EndpointConfig{
+			{Name: "default", URL: "https://default.example/"},
+		},
+	}
+
+	got := cfg.endpointFor("krnl_executeWorkflow", "")
+	if got.Name != "default" {
+		t.Errorf("endpointFor with a single endpoint = %q, want %q", got.Name, "default")
+	}
+}
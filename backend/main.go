@@ -1,17 +1,28 @@
 package main
 
 import (
-	"bytes"
 	"encoding/json"
+	"errors"
+	"flag"
 	"fmt"
-	"io"
+	"log/slog"
 	"net/http"
+	"os"
 	"time"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"golang.org/x/time/rate"
 )
 
+// requestIDHeader is the header used to correlate a request across the
+// inbound response and the outbound call to the KRNL node.
+const requestIDHeader = "X-Request-Id"
+
+var logger = slog.New(slog.NewJSONHandler(os.Stdout, nil))
+
 type WorkflowRequest struct {
 	ID      int         `json:"id"`
 	JsonRPC string      `json:"jsonrpc"`
@@ -24,6 +35,36 @@ type ProxyRequest struct {
 }
 
 func main() {
+	flagConfigPath := flag.String("c", "", "path to gateway config file (YAML or JSON)")
+	flag.Parse()
+
+	configPath := *flagConfigPath
+	explicitConfigPath := configPath != ""
+	if !explicitConfigPath {
+		if envPath := os.Getenv("KRNL_CONFIG_PATH"); envPath != "" {
+			configPath = envPath
+			explicitConfigPath = true
+		}
+	}
+
+	cfg, err := loadConfig(configPath, explicitConfigPath)
+	if err != nil {
+		logger.Error("failed to load config", "error", err)
+		os.Exit(1)
+	}
+	gw := newGateway(cfg)
+
+	workflowSchema, err := loadWorkflowSchema(cfg.Validation.SchemaPath)
+	if err != nil {
+		logger.Error("failed to load workflow schema", "error", err)
+		os.Exit(1)
+	}
+
+	jwtSecret := jwtSigningSecret(cfg.Auth)
+	nonces := newNonceStore()
+	rateLimiter := newAddressLimiter(cfg.Auth.RateLimit)
+	globalLimiter := rate.NewLimiter(rate.Limit(cfg.Auth.GlobalRateLimit.RPS), cfg.Auth.GlobalRateLimit.Burst)
+
 	r := gin.Default()
 
 	// CORS configuration
@@ -32,24 +73,102 @@ func main() {
 	config.AllowMethods = []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"}
 	config.AllowHeaders = []string{"Origin", "Content-Type", "Accept", "Authorization"}
 	config.AllowCredentials = true
+	// X-Request-Id is set by requestIDMiddleware on every response the CORS
+	// middleware lets through; without
+	// exposing it, browser fetch/XHR callers can't read it to correlate a
+	// failing call with the server's structured access logs.
+	config.ExposeHeaders = []string{requestIDHeader}
 	r.Use(cors.New(config))
 
+	// Assign a correlation ID to every request and log a structured access
+	// line once it completes.
+	r.Use(requestIDMiddleware())
+
+	// A coarse, gateway-wide limit sits in front of the per-address one:
+	// address-based limiting alone is trivially bypassed by minting a new
+	// EOA, which costs nothing.
+	r.Use(globalRateLimitMiddleware(globalLimiter))
+
+	// Prometheus metrics
+	r.GET("/metrics", gin.WrapH(promhttp.Handler()))
+
 	// Health check endpoint
 	r.GET("/health", func(c *gin.Context) {
 		c.JSON(200, gin.H{
-			"status": "healthy",
-			"time":   time.Now().UTC(),
+			"status":   "healthy",
+			"time":     time.Now().UTC(),
+			"breakers": gw.breakerStates(),
 		})
 	})
 
+	// Issues a one-time nonce the caller must embed in the SIWE message it
+	// signs for /api/auth/login, so a captured (message, signature) pair
+	// can't be replayed.
+	r.GET("/api/auth/nonce", func(c *gin.Context) {
+		nonce, err := nonces.issue()
+		if err != nil {
+			c.JSON(500, gin.H{"error": "Failed to issue nonce"})
+			return
+		}
+		c.JSON(200, gin.H{"nonce": nonce})
+	})
+
+	// Sign-In-With-Ethereum: exchange a signed EIP-4361 message for a
+	// short-lived session JWT.
+	r.POST("/api/auth/login", func(c *gin.Context) {
+		var req loginRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(400, gin.H{"error": "Invalid request body"})
+			return
+		}
+
+		address, err := verifySIWE(req.Message, req.Signature, cfg.Auth.Domain, nonces)
+		if err != nil {
+			c.JSON(401, gin.H{"error": fmt.Sprintf("SIWE verification failed: %v", err)})
+			return
+		}
+
+		token, expiresAt, err := issueToken(jwtSecret, address, time.Duration(cfg.Auth.TokenTTL))
+		if err != nil {
+			c.JSON(500, gin.H{"error": "Failed to issue session token"})
+			return
+		}
+
+		c.JSON(200, loginResponse{Token: token, Address: address, ExpiresAt: expiresAt})
+	})
+
 	// Proxy endpoint for KRNL workflow execution
-	r.POST("/api/execute-workflow", func(c *gin.Context) {
+	r.POST("/api/execute-workflow", authMiddleware(jwtSecret), rateLimitMiddleware(rateLimiter), func(c *gin.Context) {
+		requestID := c.GetString(requestIDContextKey)
+		callerAddress := c.GetString(callerAddressContextKey)
+		method := "unknown"
+
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, cfg.Validation.MaxBodyBytes)
+
+		// ProxyRequest only declares `workflow`, so ShouldBindJSON already
+		// drops any other top-level fields the caller sent.
 		var req ProxyRequest
 		if err := c.ShouldBindJSON(&req); err != nil {
+			var maxBytesErr *http.MaxBytesError
+			if errors.As(err, &maxBytesErr) {
+				requestErrorsTotal.WithLabelValues("payload_too_large").Inc()
+				requestsTotal.WithLabelValues(method, "413").Inc()
+				c.JSON(413, gin.H{"error": "request body too large"})
+				return
+			}
+			requestErrorsTotal.WithLabelValues("invalid_body").Inc()
+			requestsTotal.WithLabelValues(method, "400").Inc()
 			c.JSON(400, gin.H{"error": "Invalid request body"})
 			return
 		}
 
+		if violations := validateWorkflow(workflowSchema, req.Workflow); len(violations) > 0 {
+			requestErrorsTotal.WithLabelValues("schema_violation").Inc()
+			requestsTotal.WithLabelValues(method, "422").Inc()
+			c.JSON(422, gin.H{"error": "workflow failed schema validation", "violations": violations})
+			return
+		}
+
 		// Construct the KRNL node request
 		krnlRequest := WorkflowRequest{
 			ID:      1,
@@ -57,56 +176,89 @@ func main() {
 			Method:  "krnl_executeWorkflow",
 			Params:  []any{req.Workflow},
 		}
+		method = krnlRequest.Method
+		workflowID := extractWorkflowID(req.Workflow)
 
 		// Marshal the request
 		requestBody, err := json.Marshal(krnlRequest)
 		if err != nil {
+			requestErrorsTotal.WithLabelValues("marshal_request").Inc()
+			requestsTotal.WithLabelValues(method, "500").Inc()
 			c.JSON(500, gin.H{"error": "Failed to marshal request"})
 			return
 		}
 
-		// Create HTTP request to KRNL node
-		httpReq, err := http.NewRequest("POST", "https://v0-1-0.node.lat/", bytes.NewBuffer(requestBody))
-		if err != nil {
-			c.JSON(500, gin.H{"error": "Failed to create request"})
-			return
-		}
-
-		// Set required headers
-		httpReq.Header.Set("Accept", "application/vnd.oci.image.manifest.v1+json")
-		httpReq.Header.Set("Content-Type", "application/json")
-
-		// Execute request
-		client := &http.Client{Timeout: 60 * time.Second}
-		resp, err := client.Do(httpReq)
+		// Execute against the configured endpoint, with retries and circuit
+		// breaking handled by the gateway.
+		start := time.Now()
+		result, err := gw.call(c.Request.Context(), method, workflowID, requestID, callerAddress, requestBody)
+		forwardedDurationSeconds.WithLabelValues(method).Observe(time.Since(start).Seconds())
 		if err != nil {
-			c.JSON(500, gin.H{"error": fmt.Sprintf("Request failed: %v", err)})
+			status, reason := classifyUpstreamError(err)
+			requestErrorsTotal.WithLabelValues(reason).Inc()
+			requestsTotal.WithLabelValues(method, fmt.Sprintf("%d", status)).Inc()
+			c.JSON(status, gin.H{"error": fmt.Sprintf("Request failed: %v", err)})
 			return
 		}
-		defer resp.Body.Close()
 
-		// Read response
-		responseBody, err := io.ReadAll(resp.Body)
-		if err != nil {
-			c.JSON(500, gin.H{"error": "Failed to read response"})
+		// Parse the JSON-RPC envelope so the original id/jsonrpc fields can be
+		// echoed back and a faithful HTTP status derived from any error object.
+		var rpcResp JSONRPCResponse
+		if err := json.Unmarshal(result.body, &rpcResp); err != nil {
+			// If JSON parsing fails, return the raw response rather than masking it.
+			requestErrorsTotal.WithLabelValues("unparseable_upstream_response").Inc()
+			requestsTotal.WithLabelValues(method, fmt.Sprintf("%d", result.statusCode)).Inc()
+			c.JSON(result.statusCode, gin.H{
+				"raw_response": string(result.body),
+				"status_code":  result.statusCode,
+			})
 			return
 		}
 
-		// Parse and return response
-		var result any
-		if err := json.Unmarshal(responseBody, &result); err != nil {
-			// If JSON parsing fails, return raw response
-			c.JSON(resp.StatusCode, gin.H{
-				"raw_response": string(responseBody),
-				"status_code":  resp.StatusCode,
-			})
-			return
+		status := result.statusCode
+		if rpcResp.Error != nil {
+			status = httpStatusForRPCError(rpcResp.Error.Code)
+			requestErrorsTotal.WithLabelValues("rpc_error").Inc()
 		}
 
-		c.JSON(resp.StatusCode, result)
+		requestsTotal.WithLabelValues(method, fmt.Sprintf("%d", status)).Inc()
+		c.JSON(status, rpcResp)
 	})
 
+	// Streaming variant for workflows whose KRNL response arrives in
+	// multiple stages, forwarded to the caller as they arrive.
+	r.POST("/api/execute-workflow/stream", authMiddleware(jwtSecret), rateLimitMiddleware(rateLimiter), streamWorkflow(gw, workflowSchema, cfg.Validation.MaxBodyBytes))
+
 	fmt.Println("Backend server starting on :8080")
 	fmt.Println("CORS enabled for frontend development")
 	r.Run(":8080")
+}
+
+// requestIDContextKey is the gin context key the correlation ID is stored
+// under for the lifetime of a request.
+const requestIDContextKey = "request_id"
+
+// requestIDMiddleware assigns a correlation ID to every request (honouring
+// one supplied by the caller), echoes it back on the response, and emits a
+// structured JSON access log once the request completes.
+func requestIDMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requestID := c.GetHeader(requestIDHeader)
+		if requestID == "" {
+			requestID = uuid.NewString()
+		}
+		c.Set(requestIDContextKey, requestID)
+		c.Header(requestIDHeader, requestID)
+
+		start := time.Now()
+		c.Next()
+
+		logger.Info("request",
+			"request_id", requestID,
+			"method", c.Request.Method,
+			"path", c.Request.URL.Path,
+			"status", c.Writer.Status(),
+			"duration_ms", time.Since(start).Milliseconds(),
+		)
+	}
 }
\ No newline at end of file
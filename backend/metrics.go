@@ -0,0 +1,24 @@
+package main
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	requestsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "requests_total",
+		Help: "Total number of workflow execution requests, labelled by JSON-RPC method and the HTTP status code returned to the caller.",
+	}, []string{"method", "status"})
+
+	forwardedDurationSeconds = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "forwarded_duration_seconds",
+		Help:    "Duration of the forwarded call to the KRNL upstream node, in seconds.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"method"})
+
+	requestErrorsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "request_errors_total",
+		Help: "Total number of failed workflow execution requests, labelled by failure reason.",
+	}, []string{"reason"})
+)
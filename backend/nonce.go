@@ -0,0 +1,62 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"sync"
+	"time"
+)
+
+// nonceTTL is how long an issued SIWE nonce remains redeemable.
+const nonceTTL = 5 * time.Minute
+
+// nonceStore issues one-time SIWE nonces and tracks which are still
+// outstanding, so a captured (message, signature) pair can't be replayed
+// against /api/auth/login after the fact.
+type nonceStore struct {
+	mu     sync.Mutex
+	expiry map[string]time.Time
+}
+
+func newNonceStore() *nonceStore {
+	return &nonceStore{expiry: make(map[string]time.Time)}
+}
+
+// issue mints a new random nonce, redeemable once within nonceTTL.
+func (s *nonceStore) issue() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	nonce := hex.EncodeToString(buf)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sweepLocked()
+	s.expiry[nonce] = time.Now().Add(nonceTTL)
+	return nonce, nil
+}
+
+// consume redeems nonce if it was issued by this store and hasn't expired
+// or already been used. Redemption is one-time: a second call with the same
+// nonce always returns false.
+func (s *nonceStore) consume(nonce string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	expiresAt, ok := s.expiry[nonce]
+	if !ok {
+		return false
+	}
+	delete(s.expiry, nonce)
+	return time.Now().Before(expiresAt)
+}
+
+// sweepLocked drops expired, unredeemed nonces. Called with s.mu held.
+func (s *nonceStore) sweepLocked() {
+	now := time.Now()
+	for n, expiresAt := range s.expiry {
+		if now.After(expiresAt) {
+			delete(s.expiry, n)
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package main
+
+import "testing"
+
+func TestNonceStore_ConsumeIsOneTime(t *testing.T) {
+	store := newNonceStore()
+	nonce, err := store.issue()
+	if err != nil {
+		t.Fatalf("issue: %v", err)
+	}
+
+	if !store.consume(nonce) {
+		t.Fatal("consume(nonce) = false on first redemption, want true")
+	}
+	if store.consume(nonce) {
+		t.Fatal("consume(nonce) = true on second redemption, want false")
+	}
+}
+
+func TestNonceStore_UnknownNonceRejected(t *testing.T) {
+	store := newNonceStore()
+	if store.consume("never-issued") {
+		t.Fatal("consume of a nonce this store never issued = true, want false")
+	}
+}
+
+func TestNonceStore_ExpiredNonceRejected(t *testing.T) {
+	store := newNonceStore()
+	nonce, err := store.issue()
+	if err != nil {
+		t.Fatalf("issue: %v", err)
+	}
+
+	store.mu.Lock()
+	store.expiry[nonce] = store.expiry[nonce].Add(-2 * nonceTTL)
+	store.mu.Unlock()
+
+	if store.consume(nonce) {
+		t.Fatal("consume(expired nonce) = true, want false")
+	}
+	// Expiry is checked on redemption, so a second attempt must also fail
+	// rather than succeeding because the first call already deleted it.
+	if store.consume(nonce) {
+		t.Fatal("consume(already-rejected nonce) = true, want false")
+	}
+}
+
+func TestNonceStore_IssueProducesDistinctNonces(t *testing.T) {
+	store := newNonceStore()
+	seen := make(map[string]bool)
+	for i := 0; i < 100; i++ {
+		nonce, err := store.issue()
+		if err != nil {
+			t.Fatalf("issue: %v", err)
+		}
+		if seen[nonce] {
+			t.Fatalf("issue produced a duplicate nonce %q", nonce)
+		}
+		seen[nonce] = true
+	}
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/time/rate"
+)
+
+// addressLimiterIdleTTL is how long a per-address limiter can sit unused
+// before it's evicted. Addresses are free to mint (a new SIWE login costs
+// nothing), so this bounds the memory an attacker rotating addresses can
+// force the gateway to hold.
+const addressLimiterIdleTTL = 10 * time.Minute
+
+type limiterEntry struct {
+	limiter  *rate.Limiter
+	lastUsed time.Time
+}
+
+// addressLimiter hands out a token-bucket rate limiter per caller address,
+// creating one lazily on first use and evicting idle ones in the
+// background. Address-level limiting alone doesn't stop abuse by an
+// attacker willing to mint new addresses, so it's meant to be paired with
+// globalRateLimitMiddleware in front of it.
+type addressLimiter struct {
+	mu       sync.Mutex
+	limiters map[string]*limiterEntry
+	rps      rate.Limit
+	burst    int
+}
+
+func newAddressLimiter(cfg RateLimitConfig) *addressLimiter {
+	a := &addressLimiter{
+		limiters: make(map[string]*limiterEntry),
+		rps:      rate.Limit(cfg.RPS),
+		burst:    cfg.Burst,
+	}
+	go a.evictIdleLoop()
+	return a
+}
+
+func (a *addressLimiter) allow(address string) bool {
+	a.mu.Lock()
+	entry, ok := a.limiters[address]
+	if !ok {
+		entry = &limiterEntry{limiter: rate.NewLimiter(a.rps, a.burst)}
+		a.limiters[address] = entry
+	}
+	entry.lastUsed = time.Now()
+	limiter := entry.limiter
+	a.mu.Unlock()
+	return limiter.Allow()
+}
+
+// evictIdleLoop periodically drops limiters that haven't been used in
+// addressLimiterIdleTTL, so rotating addresses can't grow the map forever.
+func (a *addressLimiter) evictIdleLoop() {
+	ticker := time.NewTicker(addressLimiterIdleTTL)
+	defer ticker.Stop()
+	for range ticker.C {
+		a.evictIdleBefore(time.Now().Add(-addressLimiterIdleTTL))
+	}
+}
+
+// evictIdleBefore drops every limiter last used before cutoff.
+func (a *addressLimiter) evictIdleBefore(cutoff time.Time) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	for address, entry := range a.limiters {
+		if entry.lastUsed.Before(cutoff) {
+			delete(a.limiters, address)
+		}
+	}
+}
+
+// size reports how many addresses currently have a live limiter.
+func (a *addressLimiter) size() int {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return len(a.limiters)
+}
+
+// rateLimitMiddleware rejects requests once the caller address (set by
+// authMiddleware) has exhausted its token bucket.
+func rateLimitMiddleware(limiter *addressLimiter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		address := c.GetString(callerAddressContextKey)
+		if !limiter.allow(address) {
+			c.Header("Retry-After", "1")
+			c.AbortWithStatusJSON(429, gin.H{"error": fmt.Sprintf("rate limit exceeded for %s", address)})
+			return
+		}
+		c.Next()
+	}
+}
+
+// globalRateLimitMiddleware enforces one coarse, gateway-wide token bucket
+// ahead of the per-address limiter, since per-address limiting is trivially
+// bypassed by an attacker willing to mint fresh addresses.
+func globalRateLimitMiddleware(limiter *rate.Limiter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !limiter.Allow() {
+			c.Header("Retry-After", "1")
+			c.AbortWithStatusJSON(429, gin.H{"error": "gateway rate limit exceeded"})
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAddressLimiter_PerAddressBucketsAreIndependent(t *testing.T) {
+	a := &addressLimiter{
+		limiters: make(map[string]*limiterEntry),
+		rps:      1,
+		burst:    1,
+	}
+
+	if !a.allow("0xAAA") {
+		t.Fatal("first request for 0xAAA should be allowed")
+	}
+	if a.allow("0xAAA") {
+		t.Fatal("second immediate request for 0xAAA should exhaust its burst")
+	}
+	if !a.allow("0xBBB") {
+		t.Fatal("0xBBB has its own bucket and should still be allowed")
+	}
+}
+
+func TestAddressLimiter_EvictIdleBefore(t *testing.T) {
+	a := &addressLimiter{
+		limiters: make(map[string]*limiterEntry),
+		rps:      5,
+		burst:    10,
+	}
+
+	a.allow("0xAAA")
+	a.allow("0xBBB")
+
+	now := time.Now()
+	a.mu.Lock()
+	a.limiters["0xAAA"].lastUsed = now.Add(-20 * time.Minute)
+	a.limiters["0xBBB"].lastUsed = now
+	a.mu.Unlock()
+
+	a.evictIdleBefore(now.Add(-addressLimiterIdleTTL))
+
+	if a.size() != 1 {
+		t.Fatalf("size after eviction = %d, want 1", a.size())
+	}
+	a.mu.Lock()
+	_, stillPresent := a.limiters["0xBBB"]
+	a.mu.Unlock()
+	if !stillPresent {
+		t.Fatal("recently-used address 0xBBB was evicted, want it kept")
+	}
+}
+
+func TestAddressLimiter_EvictIdleBeforeKeepsEverythingWhenNoneAreIdle(t *testing.T) {
+	a := &addressLimiter{
+		limiters: make(map[string]*limiterEntry),
+		rps:      5,
+		burst:    10,
+	}
+
+	a.allow("0xAAA")
+	a.allow("0xBBB")
+
+	a.evictIdleBefore(time.Now().Add(-addressLimiterIdleTTL))
+
+	if a.size() != 2 {
+		t.Fatalf("size after no-op eviction = %d, want 2", a.size())
+	}
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"errors"
+	"net"
+
+	"github.com/sony/gobreaker"
+)
+
+// JSONRPCError is the standard JSON-RPC 2.0 error object, as returned by
+// the KRNL node when a call fails.
+type JSONRPCError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+	Data    any    `json:"data,omitempty"`
+}
+
+// JSONRPCResponse mirrors the envelope returned by the KRNL node so the
+// original `id` and `jsonrpc` fields can be passed straight back to the
+// caller, whether or not the call succeeded.
+type JSONRPCResponse struct {
+	JsonRPC string        `json:"jsonrpc"`
+	ID      any           `json:"id"`
+	Result  any           `json:"result,omitempty"`
+	Error   *JSONRPCError `json:"error,omitempty"`
+}
+
+// httpStatusForRPCError derives an HTTP status code from a JSON-RPC error
+// code, per the standard JSON-RPC 2.0 reserved error code ranges.
+func httpStatusForRPCError(code int) int {
+	switch {
+	case code == -32600:
+		return 400 // Invalid Request
+	case code == -32601:
+		return 404 // Method not found
+	case code == -32602:
+		return 400 // Invalid params
+	case code <= -32000 && code >= -32099:
+		return 502 // Server error (implementation-defined)
+	case code == -32603:
+		return 502 // Internal error
+	default:
+		return 500
+	}
+}
+
+// classifyUpstreamError maps a transport-level failure talking to the KRNL
+// node to an HTTP status and a short machine-readable reason used for the
+// request_errors_total metric.
+func classifyUpstreamError(err error) (status int, reason string) {
+	if errors.Is(err, gobreaker.ErrOpenState) || errors.Is(err, gobreaker.ErrTooManyRequests) {
+		return 503, "circuit_open"
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return 504, "upstream_timeout"
+	}
+	return 502, "upstream_unreachable"
+}
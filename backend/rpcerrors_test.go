@@ -0,0 +1,75 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+
+	"github.com/sony/gobreaker"
+)
+
+func TestHTTPStatusForRPCError(t *testing.T) {
+	cases := []struct {
+		name string
+		code int
+		want int
+	}{
+		{"invalid request", -32600, 400},
+		{"method not found", -32601, 404},
+		{"invalid params", -32602, 400},
+		{"internal error", -32603, 502},
+		{"server error range start", -32000, 502},
+		{"server error range end", -32099, 502},
+		{"server error range middle", -32050, 502},
+		{"outside server error range", -32100, 500},
+		{"unreserved application error", -1, 500},
+		{"zero", 0, 500},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := httpStatusForRPCError(tc.code); got != tc.want {
+				t.Errorf("httpStatusForRPCError(%d) = %d, want %d", tc.code, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestClassifyUpstreamError(t *testing.T) {
+	cases := []struct {
+		name       string
+		err        error
+		wantStatus int
+		wantReason string
+	}{
+		{"circuit open", gobreaker.ErrOpenState, 503, "circuit_open"},
+		{"too many requests", gobreaker.ErrTooManyRequests, 503, "circuit_open"},
+		{"timeout", context.DeadlineExceeded, 504, "upstream_timeout"},
+		{"generic failure", errors.New("connection refused"), 502, "upstream_unreachable"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			status, reason := classifyUpstreamError(tc.err)
+			if status != tc.wantStatus || reason != tc.wantReason {
+				t.Errorf("classifyUpstreamError(%v) = (%d, %q), want (%d, %q)", tc.err, status, reason, tc.wantStatus, tc.wantReason)
+			}
+		})
+	}
+}
+
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "i/o timeout" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
+
+var _ net.Error = timeoutError{}
+
+func TestClassifyUpstreamError_NetTimeout(t *testing.T) {
+	status, reason := classifyUpstreamError(timeoutError{})
+	if status != 504 || reason != "upstream_timeout" {
+		t.Errorf("classifyUpstreamError(timeoutError{}) = (%d, %q), want (504, \"upstream_timeout\")", status, reason)
+	}
+}
@@ -0,0 +1,179 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/santhosh-tekuri/jsonschema/v5"
+)
+
+// ssePingInterval is how often a heartbeat comment is sent to keep the SSE
+// connection alive through idle proxies.
+const ssePingInterval = 15 * time.Second
+
+// streamWorkflow opens an SSE connection to the caller and forwards each
+// newline-delimited JSON-RPC frame from the selected KRNL endpoint to it as
+// it arrives, instead of buffering the whole upstream response. If the
+// client disconnects, the upstream request's context is cancelled so the
+// KRNL node stops work.
+func streamWorkflow(gw *gateway, workflowSchema *jsonschema.Schema, maxBodyBytes int64) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requestID := c.GetString(requestIDContextKey)
+		callerAddress := c.GetString(callerAddressContextKey)
+
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxBodyBytes)
+
+		var req ProxyRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			var maxBytesErr *http.MaxBytesError
+			if errors.As(err, &maxBytesErr) {
+				c.JSON(413, gin.H{"error": "request body too large"})
+				return
+			}
+			c.JSON(400, gin.H{"error": "Invalid request body"})
+			return
+		}
+
+		if violations := validateWorkflow(workflowSchema, req.Workflow); len(violations) > 0 {
+			c.JSON(422, gin.H{"error": "workflow failed schema validation", "violations": violations})
+			return
+		}
+
+		krnlRequest := WorkflowRequest{
+			ID:      1,
+			JsonRPC: "2.0",
+			Method:  "krnl_executeWorkflow",
+			Params:  []any{req.Workflow},
+		}
+		method := krnlRequest.Method
+		workflowID := extractWorkflowID(req.Workflow)
+
+		requestBody, err := json.Marshal(krnlRequest)
+		if err != nil {
+			requestErrorsTotal.WithLabelValues("marshal_request").Inc()
+			requestsTotal.WithLabelValues(method, "500").Inc()
+			c.JSON(500, gin.H{"error": "Failed to marshal request"})
+			return
+		}
+
+		ctx, cancel := context.WithCancel(c.Request.Context())
+		defer cancel()
+
+		// Routed through the gateway so a tripped circuit breaker also
+		// protects the streaming path, and so this path is covered by the
+		// same metrics as the non-streaming handler.
+		start := time.Now()
+		resp, _, err := gw.openStream(ctx, method, workflowID, requestID, callerAddress, requestBody)
+		if err != nil {
+			status, reason := classifyUpstreamError(err)
+			requestErrorsTotal.WithLabelValues(reason).Inc()
+			requestsTotal.WithLabelValues(method, fmt.Sprintf("%d", status)).Inc()
+			c.JSON(status, gin.H{"error": fmt.Sprintf("Request failed: %v", err)})
+			return
+		}
+		defer resp.Body.Close()
+		forwardedDurationSeconds.WithLabelValues(method).Observe(time.Since(start).Seconds())
+
+		// Surface a faithful status for an outright HTTP failure, same as
+		// the non-streaming handler, instead of streaming the error body to
+		// the caller as if it were a normal progress frame.
+		if resp.StatusCode >= 400 {
+			body, _ := io.ReadAll(resp.Body)
+			status := resp.StatusCode
+			var rpcResp JSONRPCResponse
+			if err := json.Unmarshal(body, &rpcResp); err == nil && rpcResp.Error != nil {
+				status = httpStatusForRPCError(rpcResp.Error.Code)
+			}
+			requestErrorsTotal.WithLabelValues("rpc_error").Inc()
+			requestsTotal.WithLabelValues(method, fmt.Sprintf("%d", status)).Inc()
+			c.JSON(status, gin.H{"error": "upstream returned an error", "upstream_status": resp.StatusCode, "body": string(body)})
+			return
+		}
+
+		// Peek the first frame before committing to SSE mode: a 200 whose
+		// first JSON-RPC frame carries an `error` object should also be
+		// surfaced as an error response rather than streamed.
+		reader := bufio.NewReader(resp.Body)
+		firstLine, _ := reader.ReadString('\n')
+		firstLine = strings.TrimRight(firstLine, "\n")
+		if firstLine != "" {
+			var rpcResp JSONRPCResponse
+			if err := json.Unmarshal([]byte(firstLine), &rpcResp); err == nil && rpcResp.Error != nil {
+				status := httpStatusForRPCError(rpcResp.Error.Code)
+				requestErrorsTotal.WithLabelValues("rpc_error").Inc()
+				requestsTotal.WithLabelValues(method, fmt.Sprintf("%d", status)).Inc()
+				c.JSON(status, gin.H{"error": "upstream returned a JSON-RPC error", "rpc_error": rpcResp.Error})
+				return
+			}
+		}
+
+		requestsTotal.WithLabelValues(method, "200").Inc()
+
+		flusher, ok := c.Writer.(http.Flusher)
+		if !ok {
+			c.JSON(500, gin.H{"error": "streaming unsupported"})
+			return
+		}
+
+		c.Header("Content-Type", "text/event-stream")
+		c.Header("Cache-Control", "no-cache")
+		c.Header("Connection", "keep-alive")
+		c.Status(200)
+
+		frames := make(chan []byte)
+		go func() {
+			defer close(frames)
+			if firstLine != "" {
+				select {
+				case frames <- []byte(firstLine):
+				case <-ctx.Done():
+					return
+				}
+			}
+			scanner := bufio.NewScanner(reader)
+			scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+			for scanner.Scan() {
+				line := scanner.Bytes()
+				if len(line) == 0 {
+					continue
+				}
+				frame := make([]byte, len(line))
+				copy(frame, line)
+				select {
+				case frames <- frame:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+
+		ticker := time.NewTicker(ssePingInterval)
+		defer ticker.Stop()
+
+		eventID := 0
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case frame, ok := <-frames:
+				if !ok {
+					return
+				}
+				eventID++
+				fmt.Fprintf(c.Writer, "event: workflow-progress\nid: %d\ndata: %s\n\n", eventID, frame)
+				flusher.Flush()
+			case <-ticker.C:
+				fmt.Fprint(c.Writer, ": ping\n\n")
+				flusher.Flush()
+			}
+		}
+	}
+}
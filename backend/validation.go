@@ -0,0 +1,71 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
+)
+
+// ValidationConfig controls request body size limits and workflow schema
+// enforcement.
+type ValidationConfig struct {
+	MaxBodyBytes int64  `yaml:"max_body_bytes"`
+	SchemaPath   string `yaml:"schema_path"`
+}
+
+func defaultValidationConfig() ValidationConfig {
+	return ValidationConfig{MaxBodyBytes: 64 * 1024}
+}
+
+// loadWorkflowSchema compiles the JSON Schema used to validate the
+// `workflow` field of incoming ProxyRequests. A blank path disables schema
+// validation entirely.
+func loadWorkflowSchema(path string) (*jsonschema.Schema, error) {
+	if path == "" {
+		return nil, nil
+	}
+	if _, err := os.Stat(path); err != nil {
+		return nil, fmt.Errorf("stat workflow schema %s: %w", path, err)
+	}
+	schema, err := jsonschema.Compile(path)
+	if err != nil {
+		return nil, fmt.Errorf("compile workflow schema %s: %w", path, err)
+	}
+	return schema, nil
+}
+
+// validateWorkflow checks workflow against schema, returning one violation
+// path per failure. A nil schema (validation disabled) always passes.
+func validateWorkflow(schema *jsonschema.Schema, workflow any) []string {
+	if schema == nil {
+		return nil
+	}
+	err := schema.Validate(workflow)
+	if err == nil {
+		return nil
+	}
+	verr, ok := err.(*jsonschema.ValidationError)
+	if !ok {
+		return []string{err.Error()}
+	}
+	return violationPaths(verr)
+}
+
+// violationPaths flattens a (possibly nested) ValidationError tree into one
+// "<instance location>: <message>" entry per leaf cause.
+func violationPaths(verr *jsonschema.ValidationError) []string {
+	var paths []string
+	var walk func(e *jsonschema.ValidationError)
+	walk = func(e *jsonschema.ValidationError) {
+		if len(e.Causes) == 0 {
+			paths = append(paths, fmt.Sprintf("%s: %s", e.InstanceLocation, e.Message))
+			return
+		}
+		for _, cause := range e.Causes {
+			walk(cause)
+		}
+	}
+	walk(verr)
+	return paths
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
+)
+
+const testWorkflowSchema = `{
+	"type": "object",
+	"required": ["id", "steps"],
+	"properties": {
+		"id": {"type": "string"},
+		"steps": {
+			"type": "array",
+			"minItems": 1
+		}
+	}
+}`
+
+func compileTestSchema(t *testing.T) *jsonschema.Schema {
+	t.Helper()
+	schema, err := jsonschema.CompileString("workflow.json", testWorkflowSchema)
+	if err != nil {
+		t.Fatalf("compile test schema: %v", err)
+	}
+	return schema
+}
+
+func TestValidateWorkflow_NilSchemaAlwaysPasses(t *testing.T) {
+	if violations := validateWorkflow(nil, map[string]any{}); violations != nil {
+		t.Errorf("validateWorkflow(nil, ...) = %v, want nil", violations)
+	}
+}
+
+func TestValidateWorkflow_Valid(t *testing.T) {
+	schema := compileTestSchema(t)
+	workflow := map[string]any{
+		"id":    "real-estate-deposit",
+		"steps": []any{"collect-funds"},
+	}
+	if violations := validateWorkflow(schema, workflow); violations != nil {
+		t.Errorf("validateWorkflow(valid workflow) = %v, want nil", violations)
+	}
+}
+
+func TestValidateWorkflow_MissingRequiredFields(t *testing.T) {
+	schema := compileTestSchema(t)
+	violations := validateWorkflow(schema, map[string]any{})
+	if len(violations) == 0 {
+		t.Fatal("validateWorkflow(empty workflow) returned no violations, want at least one")
+	}
+}
+
+func TestValidateWorkflow_WrongType(t *testing.T) {
+	schema := compileTestSchema(t)
+	workflow := map[string]any{
+		"id":    "real-estate-deposit",
+		"steps": "not-an-array",
+	}
+	violations := validateWorkflow(schema, workflow)
+	if len(violations) == 0 {
+		t.Fatal("validateWorkflow(wrong-typed steps) returned no violations, want at least one")
+	}
+}
+
+func TestViolationPaths_FlattensNestedCauses(t *testing.T) {
+	schema := compileTestSchema(t)
+	err := schema.Validate(map[string]any{"steps": []any{}})
+	if err == nil {
+		t.Fatal("expected validation to fail")
+	}
+	verr, ok := err.(*jsonschema.ValidationError)
+	if !ok {
+		t.Fatalf("expected *jsonschema.ValidationError, got %T", err)
+	}
+
+	paths := violationPaths(verr)
+	if len(paths) == 0 {
+		t.Fatal("violationPaths returned no entries, want at least one per leaf cause")
+	}
+	for _, p := range paths {
+		if p == "" {
+			t.Errorf("violationPaths produced an empty entry in %v", paths)
+		}
+	}
+}